@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// AuxiliaryFields reads points off of a single input edge and fans out the
+// requested fields onto one output edge per field so each projected
+// expression can be read independently.
+type AuxiliaryFields struct {
+	Input  *OutputEdge
+	Output *InputEdge
+
+	fields []auxField
+
+	execTiming
+}
+
+type auxField struct {
+	Ref    *influxql.VarRef
+	Output *InputEdge
+}
+
+// Iterator registers ref as a field this node needs to emit and returns the
+// output edge that will carry its values.
+func (c *AuxiliaryFields) Iterator(ref *influxql.VarRef) *OutputEdge {
+	in, out := NewEdge(c)
+	c.fields = append(c.fields, auxField{Ref: ref, Output: in})
+	return out
+}
+
+// Execute reads points from Input and republishes each one to every
+// registered field's output edge, and to Output when this node has been
+// spliced after a function call, checking ctx.Done() between points.
+func (c *AuxiliaryFields) Execute(ctx context.Context) error {
+	c.started()
+	defer c.stopped()
+	defer func() {
+		for _, f := range c.fields {
+			f.Output.Close()
+		}
+		if c.Output != nil {
+			c.Output.Close()
+		}
+	}()
+	defer func() { recordNodeStats(ctx, c) }()
+
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		p, ok, err := c.Input.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !ok {
+			return nil
+		}
+
+		for _, f := range c.fields {
+			if err := f.Output.Emit(ctx, p); err != nil {
+				return contextError(ctx)
+			}
+		}
+		if c.Output != nil {
+			if err := c.Output.Emit(ctx, p); err != nil {
+				return contextError(ctx)
+			}
+		}
+	}
+}
+
+// Stats returns the counters for Input summed against the counters for
+// every registered field's output edge.
+func (c *AuxiliaryFields) Stats() NodeStats {
+	in := c.Input.Stats()
+	stats := NodeStats{PointsIn: in.Points}
+	for _, f := range c.fields {
+		s := f.Output.Stats()
+		stats.PointsOut += s.Points
+		stats.BytesOut += s.Bytes
+	}
+	stats.WallTime = c.wallTime()
+	return stats
+}
+
+// Label identifies this node; it does not carry a single field name since
+// it fans out to every auxiliary field at once.
+func (c *AuxiliaryFields) Label() string {
+	return "AuxiliaryFields"
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// evalBinaryExpr applies op to two values read off of an edge. Only the
+// numeric operators needed by the plan nodes in this package are
+// implemented; anything else returns nil.
+func evalBinaryExpr(op influxql.Token, lhs, rhs interface{}) interface{} {
+	lf, lok := toFloat64(lhs)
+	rf, rok := toFloat64(rhs)
+	if !lok || !rok {
+		return nil
+	}
+
+	switch op {
+	case influxql.ADD:
+		return lf + rf
+	case influxql.SUB:
+		return lf - rf
+	case influxql.MUL:
+		return lf * rf
+	case influxql.DIV:
+		if rf == 0 {
+			return float64(0)
+		}
+		return lf / rf
+	default:
+		return nil
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// BinaryExpr evaluates a binary expression, such as value * 2, by reading
+// one point from each of LHS and RHS and combining them with Op.
+type BinaryExpr struct {
+	LHS, RHS *OutputEdge
+	Op       influxql.Token
+	Output   *InputEdge
+
+	execTiming
+}
+
+// Execute reads matching points from LHS and RHS, applies Op, and writes
+// the combined point to Output, checking ctx.Done() between points.
+func (n *BinaryExpr) Execute(ctx context.Context) error {
+	n.started()
+	defer n.stopped()
+	defer n.Output.Close()
+	defer func() { recordNodeStats(ctx, n) }()
+
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		lhs, lok, err := n.LHS.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		rhs, rok, err := n.RHS.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !lok || !rok {
+			return nil
+		}
+
+		p := Point{Name: lhs.Name, Tags: lhs.Tags, Time: lhs.Time}
+		p.Value = evalBinaryExpr(n.Op, lhs.Value, rhs.Value)
+		if err := n.Output.Emit(ctx, p); err != nil {
+			return contextError(ctx)
+		}
+	}
+}
+
+// Stats returns the counters for LHS and RHS summed together alongside the
+// counters for Output.
+func (n *BinaryExpr) Stats() NodeStats {
+	lhs := n.LHS.Stats()
+	rhs := n.RHS.Stats()
+	out := n.Output.Stats()
+	return NodeStats{
+		PointsIn:  lhs.Points + rhs.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  n.wallTime(),
+	}
+}
+
+// Label identifies this node by the operator it evaluates.
+func (n *BinaryExpr) Label() string {
+	return "BinaryExpr(" + n.Op.String() + ")"
+}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -22,6 +23,11 @@ type compiledStatement struct {
 	// used in the statement.
 	TopBottomFunction string
 
+	// TopBottomTags holds the tag arguments passed to top()/bottom(), if
+	// any. Auxiliary fields requested alongside a top()/bottom() call must
+	// be limited to these tags.
+	TopBottomTags []*influxql.VarRef
+
 	// AuxFields holds a mapping to the auxiliary fields that need to be
 	// selected. This maps the raw VarRef to a pointer to a shared VarRef. The
 	// pointer is used for instantiating references to the shared variable so
@@ -35,16 +41,42 @@ type compiledStatement struct {
 	// OutputEdges holds the outermost edges that will be used to read from
 	// when returning results.
 	OutputEdges []*OutputEdge
+
+	// FieldOutputs maps a field's name (or alias) to its compiled output
+	// edge. It is used to resolve VarRefs against this statement when it is
+	// compiled as a subquery source of an outer statement.
+	FieldOutputs map[string]*OutputEdge
+
+	// Condition holds the statement's WHERE clause, if any. It is carried
+	// through compilation so it can be pushed down into subquery sources.
+	Condition influxql.Expr
+
+	// Expander, if set, is used to flatten regex and multi-measurement
+	// sources into concrete measurements before compiling fields.
+	Expander MeasurementExpander
+
+	// Nodes holds every plan node instantiated while compiling this
+	// statement, including those belonging to subquery sources. Select
+	// copies this onto the Plan so an executor can run the whole graph.
+	Nodes []Node
+}
+
+// addNode records node as part of this statement's plan so it will be run
+// once the plan is executed.
+func (c *compiledStatement) addNode(node Node) {
+	c.Nodes = append(c.Nodes, node)
 }
 
 type CompiledStatement interface {
-	Select(plan *Plan) ([]*OutputEdge, error)
+	Select(ctx context.Context, plan *Plan) ([]*OutputEdge, error)
 }
 
 func newCompiler(stmt *influxql.SelectStatement) *compiledStatement {
 	return &compiledStatement{
 		OnlySelectors: true,
 		OutputEdges:   make([]*OutputEdge, 0, len(stmt.Fields)),
+		FieldOutputs:  make(map[string]*OutputEdge, len(stmt.Fields)),
+		Condition:     stmt.Condition,
 	}
 }
 
@@ -54,19 +86,50 @@ func (c *compiledStatement) compileExpr(expr influxql.Expr) (*OutputEdge, error)
 		// If there is no instance of AuxiliaryFields, instantiate one now.
 		if c.AuxiliaryFields == nil {
 			c.AuxiliaryFields = &AuxiliaryFields{}
+			c.addNode(c.AuxiliaryFields)
 		}
 		return c.AuxiliaryFields.Iterator(expr), nil
 	case *influxql.Call:
 		switch expr.Name {
 		case "count", "min", "max", "sum", "first", "last", "mean":
 			return c.compileFunction(expr)
+		case "top", "bottom":
+			return c.compileTopBottom(expr)
+		case "percentile":
+			return c.compilePercentile(expr)
+		case "sample":
+			return c.compileSample(expr)
+		case "distinct":
+			return c.compileDistinct(expr)
 		default:
 			return nil, errors.New("unimplemented")
 		}
 	case *influxql.BinaryExpr:
 		// Check if either side is a literal so we only compile one side if it is.
-		if _, ok := expr.LHS.(influxql.Literal); ok {
-		} else if _, ok := expr.RHS.(influxql.Literal); ok {
+		if lit, ok := expr.LHS.(influxql.Literal); ok {
+			rhs, err := c.compileExpr(expr.RHS)
+			if err != nil {
+				return nil, err
+			}
+			node := &LiteralBinaryExpr{Side: LHS, Literal: lit, Op: expr.Op, Input: rhs}
+			rhs.Node = node
+			c.addNode(node)
+
+			var out *OutputEdge
+			node.Output, out = NewEdge(node)
+			return out, nil
+		} else if lit, ok := expr.RHS.(influxql.Literal); ok {
+			lhs, err := c.compileExpr(expr.LHS)
+			if err != nil {
+				return nil, err
+			}
+			node := &LiteralBinaryExpr{Side: RHS, Literal: lit, Op: expr.Op, Input: lhs}
+			lhs.Node = node
+			c.addNode(node)
+
+			var out *OutputEdge
+			node.Output, out = NewEdge(node)
+			return out, nil
 		} else {
 			lhs, err := c.compileExpr(expr.LHS)
 			if err != nil {
@@ -78,6 +141,7 @@ func (c *compiledStatement) compileExpr(expr influxql.Expr) (*OutputEdge, error)
 			}
 			node := &BinaryExpr{LHS: lhs, RHS: rhs, Op: expr.Op}
 			lhs.Node, rhs.Node = node, node
+			c.addNode(node)
 
 			var out *OutputEdge
 			node.Output, out = NewEdge(node)
@@ -95,7 +159,7 @@ func (c *compiledStatement) compileFunction(expr *influxql.Call) (*OutputEdge, e
 	// If we have count(), the argument may be a distinct() call.
 	if expr.Name == "count" {
 		if arg0, ok := expr.Args[0].(*influxql.Call); ok && arg0.Name == "distinct" {
-			return nil, errors.New("unimplemented")
+			return c.compileCountDistinct(arg0)
 		}
 	}
 
@@ -105,34 +169,311 @@ func (c *compiledStatement) compileFunction(expr *influxql.Call) (*OutputEdge, e
 		return nil, fmt.Errorf("expected field argument in %s()", expr.Name)
 	}
 
+	aggregate := true
+	switch expr.Name {
+	case "max", "min", "first", "last":
+		aggregate = false
+	}
+
+	merge, err := c.compileSourceMerge(arg0, aggregate)
+	if err != nil {
+		return nil, err
+	}
+	call := &FunctionCall{Name: expr.Name}
+	merge.Output, call.Input = AddEdge(merge, call)
+	c.addNode(call)
+
+	// Mark down some meta properties related to the function for query validation.
+	if aggregate {
+		c.OnlySelectors = false
+	}
+
+	var out *OutputEdge
+	call.Output, out = NewEdge(call)
+	c.FunctionCalls = append(c.FunctionCalls, out)
+	return out, nil
+}
+
+// compileTopBottom compiles top(field, [tag, ...], N) and the equivalent
+// bottom() call. The trailing argument must be an integer literal N; any
+// arguments between the field and N must be tag references, which are
+// carried alongside the grouped rows so they can be selected as aux
+// fields. The plan is built as Merge -> TopBottomReduce -> TopBottomFinal
+// so that a per-shard top-K can be computed before the final merge.
+func (c *compiledStatement) compileTopBottom(expr *influxql.Call) (*OutputEdge, error) {
+	if len(expr.Args) < 2 {
+		return nil, fmt.Errorf("invalid number of arguments for %s, expected at least 2, got %d", expr.Name, len(expr.Args))
+	}
+
+	arg0, ok := expr.Args[0].(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("expected first argument to be a field in %s()", expr.Name)
+	}
+
+	nArg, ok := expr.Args[len(expr.Args)-1].(*influxql.IntegerLiteral)
+	if !ok {
+		return nil, fmt.Errorf("expected %s() to end with an integer limit", expr.Name)
+	}
+	if nArg.Val <= 0 {
+		return nil, fmt.Errorf("limit (%d) for %s() must be greater than 0", nArg.Val, expr.Name)
+	}
+
+	tags := make([]*influxql.VarRef, 0, len(expr.Args)-2)
+	for _, arg := range expr.Args[1 : len(expr.Args)-1] {
+		tag, ok := arg.(*influxql.VarRef)
+		if !ok {
+			return nil, fmt.Errorf("expected tag argument in %s()", expr.Name)
+		}
+		tags = append(tags, tag)
+	}
+
+	merge, err := c.compileSourceMerge(arg0, false)
+	if err != nil {
+		return nil, err
+	}
+	reduce := &TopBottomReduce{Name: expr.Name, Tags: tags, N: int(nArg.Val)}
+	merge.Output, reduce.Input = AddEdge(merge, reduce)
+	c.addNode(reduce)
+
+	final := &TopBottomFinal{Name: expr.Name, Tags: tags, N: int(nArg.Val)}
+	reduce.Output, final.Input = AddEdge(reduce, final)
+	c.addNode(final)
+
+	if c.TopBottomFunction == "" {
+		c.TopBottomFunction = expr.Name
+		c.TopBottomTags = tags
+	}
+
+	var out *OutputEdge
+	final.Output, out = NewEdge(final)
+	c.FunctionCalls = append(c.FunctionCalls, out)
+	return out, nil
+}
+
+// compilePercentile compiles percentile(field, p), where p is a numeric
+// literal in the range (0, 100].
+func (c *compiledStatement) compilePercentile(expr *influxql.Call) (*OutputEdge, error) {
+	if exp, got := 2, len(expr.Args); exp != got {
+		return nil, fmt.Errorf("invalid number of arguments for percentile, expected %d, got %d", exp, got)
+	}
+
+	arg0, ok := expr.Args[0].(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("expected field argument in percentile()")
+	}
+
+	var percentile float64
+	switch lit := expr.Args[1].(type) {
+	case *influxql.IntegerLiteral:
+		percentile = float64(lit.Val)
+	case *influxql.NumberLiteral:
+		percentile = lit.Val
+	default:
+		return nil, fmt.Errorf("expected float argument in percentile()")
+	}
+	if percentile <= 0 || percentile > 100 {
+		return nil, fmt.Errorf("percentile (%v) must be in the range (0, 100]", percentile)
+	}
+
+	merge, err := c.compileSourceMerge(arg0, false)
+	if err != nil {
+		return nil, err
+	}
+	call := &FunctionCall{Name: "percentile", Args: []interface{}{percentile}}
+	merge.Output, call.Input = AddEdge(merge, call)
+	c.addNode(call)
+
+	var out *OutputEdge
+	call.Output, out = NewEdge(call)
+	c.FunctionCalls = append(c.FunctionCalls, out)
+	return out, nil
+}
+
+// compileSample compiles sample(field, k), where k is an integer literal
+// greater than or equal to 1.
+func (c *compiledStatement) compileSample(expr *influxql.Call) (*OutputEdge, error) {
+	if exp, got := 2, len(expr.Args); exp != got {
+		return nil, fmt.Errorf("invalid number of arguments for sample, expected %d, got %d", exp, got)
+	}
+
+	arg0, ok := expr.Args[0].(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("expected field argument in sample()")
+	}
+
+	kArg, ok := expr.Args[1].(*influxql.IntegerLiteral)
+	if !ok {
+		return nil, fmt.Errorf("expected integer argument for sample size")
+	}
+	if kArg.Val < 1 {
+		return nil, fmt.Errorf("sample size (%d) must be at least 1", kArg.Val)
+	}
+
+	merge, err := c.compileSourceMerge(arg0, false)
+	if err != nil {
+		return nil, err
+	}
+	call := &FunctionCall{Name: "sample", Args: []interface{}{int(kArg.Val)}}
+	merge.Output, call.Input = AddEdge(merge, call)
+	c.addNode(call)
+
+	var out *OutputEdge
+	call.Output, out = NewEdge(call)
+	c.FunctionCalls = append(c.FunctionCalls, out)
+	return out, nil
+}
+
+// compileSourceMerge builds a Merge with one input per source in the
+// statement, each reading expr. expr may be nil for an IteratorCreator that
+// only needs to feed auxiliary fields, but a nil expr cannot be resolved
+// against a SubQuery source since there is no single field to look up.
+// aggregate indicates whether expr is about to be reduced by an aggregate
+// function, which subqueries that are themselves already aggregated
+// cannot support.
+func (c *compiledStatement) compileSourceMerge(expr influxql.Expr, aggregate bool) (*Merge, error) {
 	merge := &Merge{}
+	c.addNode(merge)
 	for _, source := range c.Sources {
 		switch source := source.(type) {
 		case *influxql.Measurement:
+			if source.Regex != nil {
+				return nil, fmt.Errorf("a MeasurementExpander is required to resolve regex measurement %q", source.Regex.Val.String())
+			}
+			if source.Name == "" {
+				return nil, fmt.Errorf("a MeasurementExpander is required to resolve this source")
+			}
+
 			ic := &IteratorCreator{
-				Expr:            arg0,
+				Expr:            expr,
 				AuxiliaryFields: &c.AuxiliaryFields,
 				Measurement:     source,
+				Condition:       c.Condition,
 			}
 			ic.Output = merge.AddInput(ic)
+			c.addNode(ic)
+		case *influxql.SubQuery:
+			if expr == nil {
+				return nil, fmt.Errorf("selecting every field from a subquery is not supported; reference each field explicitly")
+			}
+			out, err := c.compileSubquery(source, expr, aggregate)
+			if err != nil {
+				return nil, err
+			}
+			merge.AddInputEdge(out)
 		default:
-			panic("unimplemented")
+			return nil, fmt.Errorf("unsupported source type: %T", source)
 		}
 	}
-	call := &FunctionCall{Name: expr.Name}
-	merge.Output, call.Input = AddEdge(merge, call)
+	return merge, nil
+}
 
-	// Mark down some meta properties related to the function for query validation.
-	switch expr.Name {
-	case "top", "bottom":
-		if c.TopBottomFunction == "" {
-			c.TopBottomFunction = expr.Name
+// compileSubquery compiles sq's inner statement and resolves expr (which
+// must be a plain field reference) against its projected fields. The outer
+// statement's WHERE clause is pushed down into the subquery only when it is
+// entirely made up of time bounds, since both share the same measurement
+// time bounds; any other predicate is rejected rather than pushed down,
+// since it may reference a tag or field that doesn't resolve the same way
+// against the subquery's (possibly aggregated, possibly renamed) output.
+func (c *compiledStatement) compileSubquery(sq *influxql.SubQuery, expr influxql.Expr, aggregate bool) (*OutputEdge, error) {
+	ref, ok := expr.(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("only a field reference can be resolved against a subquery source")
+	}
+
+	inner := sq.Statement
+	if c.Condition != nil {
+		if !conditionReferencesOnlyTime(c.Condition) {
+			return nil, fmt.Errorf("cannot push non-time condition down into subquery for %q", ref.Val)
 		}
-	case "max", "min", "first", "last", "percentile", "sample":
-	default:
-		c.OnlySelectors = false
+
+		clone := *inner
+		if clone.Condition != nil {
+			clone.Condition = &influxql.BinaryExpr{Op: influxql.AND, LHS: clone.Condition, RHS: c.Condition}
+		} else {
+			clone.Condition = c.Condition
+		}
+		inner = &clone
+	}
+
+	var opts []CompileOption
+	if c.Expander != nil {
+		opts = append(opts, WithMeasurementExpander(c.Expander))
+	}
+
+	compiled, err := Compile(inner, opts...)
+	if err != nil {
+		return nil, err
+	}
+	child := compiled.(*compiledStatement)
+	c.Nodes = append(c.Nodes, child.Nodes...)
+
+	if aggregate && !child.OnlySelectors {
+		return nil, fmt.Errorf("cannot aggregate %q: subquery is already aggregated on a coarser interval", ref.Val)
+	}
+
+	out, ok := child.FieldOutputs[ref.Val]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference to %q in subquery", ref.Val)
+	}
+	return out, nil
+}
+
+// compileDistinct compiles a bare distinct(field) field, such as in
+// SELECT distinct(value) FROM cpu. It sets c.Distinct so that
+// validateFields can enforce that distinct() isn't mixed with auxiliary
+// fields or other function calls.
+func (c *compiledStatement) compileDistinct(expr *influxql.Call) (*OutputEdge, error) {
+	if exp, got := 1, len(expr.Args); exp != got {
+		return nil, fmt.Errorf("distinct function requires exactly one argument")
 	}
 
+	ref, ok := expr.Args[0].(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("expected field argument in distinct()")
+	}
+
+	merge, err := c.compileSourceMerge(ref, false)
+	if err != nil {
+		return nil, err
+	}
+	distinct := &Distinct{Ref: ref}
+	merge.Output, distinct.Input = AddEdge(merge, distinct)
+	c.addNode(distinct)
+
+	c.Distinct = distinct
+	c.OnlySelectors = false
+
+	var out *OutputEdge
+	distinct.Output, out = NewEdge(distinct)
+	return out, nil
+}
+
+// compileCountDistinct compiles count(distinct(field)), composing the plan
+// as FunctionCall{count} <- Distinct <- Merge <- IteratorCreator so that
+// count only ever sees one point per unique value.
+func (c *compiledStatement) compileCountDistinct(expr *influxql.Call) (*OutputEdge, error) {
+	if exp, got := 1, len(expr.Args); exp != got {
+		return nil, fmt.Errorf("distinct function requires exactly one argument")
+	}
+
+	ref, ok := expr.Args[0].(*influxql.VarRef)
+	if !ok {
+		return nil, fmt.Errorf("expected field argument in distinct()")
+	}
+
+	merge, err := c.compileSourceMerge(ref, true)
+	if err != nil {
+		return nil, err
+	}
+	distinct := &Distinct{Ref: ref}
+	merge.Output, distinct.Input = AddEdge(merge, distinct)
+	c.addNode(distinct)
+
+	call := &FunctionCall{Name: "count"}
+	distinct.Output, call.Input = AddEdge(distinct, call)
+	c.addNode(call)
+	c.OnlySelectors = false
+
 	var out *OutputEdge
 	call.Output, out = NewEdge(call)
 	c.FunctionCalls = append(c.FunctionCalls, out)
@@ -141,14 +482,19 @@ func (c *compiledStatement) compileFunction(expr *influxql.Call) (*OutputEdge, e
 
 func (c *compiledStatement) linkAuxiliaryFields() error {
 	if c.AuxiliaryFields == nil {
-		if len(c.FunctionCalls) == 0 {
+		if len(c.FunctionCalls) == 0 && c.Distinct == nil {
 			return errors.New("at least 1 non-time field must be queried")
 		}
 		return nil
 	}
 
 	if c.AuxiliaryFields != nil {
-		if !c.OnlySelectors {
+		// Check this ahead of the generic OnlySelectors check below so
+		// mixing distinct() with another field reports the reason specific
+		// to distinct() rather than the generic aggregate-mixing message.
+		if c.Distinct != nil {
+			return errors.New("aux fields cannot be combined with distinct")
+		} else if !c.OnlySelectors {
 			return fmt.Errorf("mixing aggregate and non-aggregate queries is not supported")
 		} else if len(c.FunctionCalls) > 1 {
 			return fmt.Errorf("mixing multiple selector functions with tags or fields is not supported")
@@ -158,18 +504,9 @@ func (c *compiledStatement) linkAuxiliaryFields() error {
 			c.AuxiliaryFields.Input, c.AuxiliaryFields.Output = c.FunctionCalls[0].Insert(c.AuxiliaryFields)
 		} else {
 			// Create a default IteratorCreator for this AuxiliaryFields.
-			merge := &Merge{}
-			for _, source := range c.Sources {
-				switch source := source.(type) {
-				case *influxql.Measurement:
-					ic := &IteratorCreator{
-						AuxiliaryFields: &c.AuxiliaryFields,
-						Measurement:     source,
-					}
-					ic.Output = merge.AddInput(ic)
-				default:
-					panic("unimplemented")
-				}
+			merge, err := c.compileSourceMerge(nil, false)
+			if err != nil {
+				return err
 			}
 			merge.Output, c.AuxiliaryFields.Input = AddEdge(merge, c.AuxiliaryFields)
 		}
@@ -182,13 +519,78 @@ func (c *compiledStatement) validateFields() error {
 	if len(c.FunctionCalls) > 1 && c.TopBottomFunction != "" {
 		return fmt.Errorf("selector function %s() cannot be combined with other functions", c.TopBottomFunction)
 	}
+
+	// Aux fields combined with top()/bottom() must be limited to the tags
+	// listed as arguments to the selector.
+	if c.TopBottomFunction != "" && c.AuxiliaryFields != nil {
+		for _, f := range c.AuxiliaryFields.fields {
+			if !refsContain(c.TopBottomTags, f.Ref) {
+				return fmt.Errorf("mixing %s() with %q is not supported unless %q is listed as a tag argument",
+					c.TopBottomFunction, f.Ref.Val, f.Ref.Val)
+			}
+		}
+	}
+
+	// distinct() cannot be combined with auxiliary fields or other function
+	// calls.
+	if c.Distinct != nil {
+		if c.AuxiliaryFields != nil {
+			return errors.New("aux fields cannot be combined with distinct")
+		} else if len(c.FunctionCalls) > 0 {
+			return errors.New("aggregate functions cannot be combined with distinct")
+		}
+	}
 	return nil
 }
 
-func Compile(stmt *influxql.SelectStatement) (CompiledStatement, error) {
-	// Compile each of the expressions.
+// refsContain reports whether refs contains a VarRef with the same name as
+// ref.
+func refsContain(refs []*influxql.VarRef, ref *influxql.VarRef) bool {
+	for _, r := range refs {
+		if r.Val == ref.Val {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionReferencesOnlyTime reports whether cond is built entirely out of
+// AND-ed comparisons against the "time" field. This is the only shape of
+// condition compileSubquery considers safe to push down into a subquery's
+// inner statement.
+func conditionReferencesOnlyTime(cond influxql.Expr) bool {
+	expr, ok := cond.(*influxql.BinaryExpr)
+	if !ok {
+		return false
+	}
+	if expr.Op == influxql.AND {
+		return conditionReferencesOnlyTime(expr.LHS) && conditionReferencesOnlyTime(expr.RHS)
+	}
+
+	ref, ok := expr.LHS.(*influxql.VarRef)
+	if !ok {
+		ref, ok = expr.RHS.(*influxql.VarRef)
+	}
+	return ok && ref.Val == "time"
+}
+
+func Compile(stmt *influxql.SelectStatement, opts ...CompileOption) (CompiledStatement, error) {
 	c := newCompiler(stmt)
-	c.Sources = append(c.Sources, stmt.Sources...)
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	sources := stmt.Sources
+	if c.Expander != nil {
+		expanded, err := c.Expander.ExpandSources(sources)
+		if err != nil {
+			return nil, err
+		}
+		sources = expanded
+	}
+	c.Sources = append(c.Sources, sources...)
+
+	// Compile each of the expressions.
 	for _, f := range stmt.Fields {
 		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" {
 			continue
@@ -199,6 +601,7 @@ func Compile(stmt *influxql.SelectStatement) (CompiledStatement, error) {
 			return nil, err
 		}
 		c.OutputEdges = append(c.OutputEdges, out)
+		c.FieldOutputs[f.Name()] = out
 	}
 
 	if err := c.linkAuxiliaryFields(); err != nil {
@@ -210,9 +613,56 @@ func Compile(stmt *influxql.SelectStatement) (CompiledStatement, error) {
 	return c, nil
 }
 
-func (c *compiledStatement) Select(plan *Plan) ([]*OutputEdge, error) {
+func (c *compiledStatement) Select(ctx context.Context, plan *Plan) ([]*OutputEdge, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+
+	plan.stats = newPlanStats()
+	plan.Nodes = c.Nodes
 	for _, out := range c.OutputEdges {
 		plan.AddTarget(out)
 	}
 	return c.OutputEdges, nil
 }
+
+// ExplainAnalyze compiles and fully executes stmt's inner statement purely
+// to gather Stats from every plan node, backing EXPLAIN ANALYZE SELECT ...
+func ExplainAnalyze(ctx context.Context, stmt *influxql.ExplainStatement, opts ...CompileOption) (map[string]NodeStats, error) {
+	if !stmt.Analyze {
+		return nil, errors.New("EXPLAIN must be given ANALYZE to produce statistics")
+	}
+
+	compiled, err := Compile(stmt.Statement, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	targets, err := compiled.Select(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every node must be running before we start draining targets below, or
+	// a node earlier in the graph will block forever trying to write to an
+	// edge nobody is reading from yet.
+	wait := plan.Execute(ctx)
+
+	for _, out := range targets {
+		for {
+			_, ok, err := out.Next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+	}
+
+	if err := wait(); err != nil {
+		return nil, err
+	}
+	return plan.Stats(), nil
+}
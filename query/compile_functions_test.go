@@ -0,0 +1,128 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+func TestCompile_TopBottom(t *testing.T) {
+	for _, name := range []string{"top", "bottom"} {
+		fields := influxql.Fields{
+			{Expr: &influxql.Call{Name: name, Args: []influxql.Expr{
+				&influxql.VarRef{Val: "value"},
+				&influxql.VarRef{Val: "host"},
+				&influxql.IntegerLiteral{Val: 5},
+			}}},
+		}
+		compiled := compileSelect(t, fields)
+		c := compiled.(*compiledStatement)
+
+		if c.TopBottomFunction != name {
+			t.Errorf("expected TopBottomFunction %q, got %q", name, c.TopBottomFunction)
+		}
+		if len(c.TopBottomTags) != 1 || c.TopBottomTags[0].Val != "host" {
+			t.Errorf("unexpected TopBottomTags: %v", c.TopBottomTags)
+		}
+
+		var reduce *TopBottomReduce
+		var final *TopBottomFinal
+		for _, n := range c.Nodes {
+			switch n := n.(type) {
+			case *TopBottomReduce:
+				reduce = n
+			case *TopBottomFinal:
+				final = n
+			}
+		}
+		if reduce == nil || reduce.N != 5 {
+			t.Fatalf("expected a TopBottomReduce with N=5, got %+v", reduce)
+		}
+		if final == nil || final.N != 5 {
+			t.Fatalf("expected a TopBottomFinal with N=5, got %+v", final)
+		}
+	}
+}
+
+func TestCompile_TopBottomErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []influxql.Expr
+	}{
+		{"too few arguments", []influxql.Expr{&influxql.VarRef{Val: "value"}}},
+		{"non-field first argument", []influxql.Expr{&influxql.IntegerLiteral{Val: 1}, &influxql.IntegerLiteral{Val: 5}}},
+		{"non-integer limit", []influxql.Expr{&influxql.VarRef{Val: "value"}, &influxql.StringLiteral{Val: "5"}}},
+		{"zero limit", []influxql.Expr{&influxql.VarRef{Val: "value"}, &influxql.IntegerLiteral{Val: 0}}},
+		{"non-field tag argument", []influxql.Expr{&influxql.VarRef{Val: "value"}, &influxql.IntegerLiteral{Val: 1}, &influxql.IntegerLiteral{Val: 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := &influxql.SelectStatement{
+				Fields:  influxql.Fields{{Expr: &influxql.Call{Name: "top", Args: tt.args}}},
+				Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+			}
+			if _, err := Compile(stmt); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestCompile_Percentile(t *testing.T) {
+	fields := influxql.Fields{
+		{Expr: &influxql.Call{Name: "percentile", Args: []influxql.Expr{
+			&influxql.VarRef{Val: "value"},
+			&influxql.NumberLiteral{Val: 90},
+		}}},
+	}
+	compiled := compileSelect(t, fields)
+	c := compiled.(*compiledStatement)
+
+	if len(c.FunctionCalls) != 1 {
+		t.Fatalf("expected 1 function call, got %d", len(c.FunctionCalls))
+	}
+}
+
+func TestCompile_PercentileOutOfRange(t *testing.T) {
+	for _, p := range []float64{0, -1, 100.1} {
+		stmt := &influxql.SelectStatement{
+			Fields: influxql.Fields{{Expr: &influxql.Call{Name: "percentile", Args: []influxql.Expr{
+				&influxql.VarRef{Val: "value"},
+				&influxql.NumberLiteral{Val: p},
+			}}}},
+			Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+		}
+		if _, err := Compile(stmt); err == nil {
+			t.Errorf("expected an error for percentile %v", p)
+		}
+	}
+}
+
+func TestCompile_Sample(t *testing.T) {
+	fields := influxql.Fields{
+		{Expr: &influxql.Call{Name: "sample", Args: []influxql.Expr{
+			&influxql.VarRef{Val: "value"},
+			&influxql.IntegerLiteral{Val: 3},
+		}}},
+	}
+	compiled := compileSelect(t, fields)
+	c := compiled.(*compiledStatement)
+
+	if len(c.FunctionCalls) != 1 {
+		t.Fatalf("expected 1 function call, got %d", len(c.FunctionCalls))
+	}
+}
+
+func TestCompile_SampleSizeTooSmall(t *testing.T) {
+	stmt := &influxql.SelectStatement{
+		Fields: influxql.Fields{{Expr: &influxql.Call{Name: "sample", Args: []influxql.Expr{
+			&influxql.VarRef{Val: "value"},
+			&influxql.IntegerLiteral{Val: 0},
+		}}}},
+		Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+	}
+	if _, err := Compile(stmt); err == nil {
+		t.Fatal("expected an error for a sample size below 1")
+	}
+}
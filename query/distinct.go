@@ -0,0 +1,83 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// Distinct reads points from Input and writes only the first point seen
+// for each unique value, tracked independently per series.
+type Distinct struct {
+	Ref    *influxql.VarRef
+	Input  *OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// Execute reads every point from Input, discarding any whose value has
+// already been seen for that point's series, and writes the rest to
+// Output.
+func (d *Distinct) Execute(ctx context.Context) error {
+	d.started()
+	defer d.stopped()
+	defer d.Output.Close()
+	defer func() { recordNodeStats(ctx, d) }()
+
+	seen := make(map[string]map[interface{}]bool)
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		p, ok, err := d.Input.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !ok {
+			return nil
+		}
+
+		key := seriesKey(p)
+		values, ok := seen[key]
+		if !ok {
+			values = make(map[interface{}]bool)
+			seen[key] = values
+		}
+		if values[p.Value] {
+			continue
+		}
+		values[p.Value] = true
+
+		if err := d.Output.Emit(ctx, p); err != nil {
+			return contextError(ctx)
+		}
+	}
+}
+
+// seriesKey returns a string uniquely identifying the series a point
+// belongs to, so distinct() and similar per-series tracking can use it as a
+// map key.
+func seriesKey(p Point) string {
+	return fmt.Sprintf("%s\x00%v", p.Name, p.Tags)
+}
+
+// Stats returns the counters for Input and Output.
+func (d *Distinct) Stats() NodeStats {
+	in := d.Input.Stats()
+	out := d.Output.Stats()
+	return NodeStats{
+		PointsIn:  in.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  d.wallTime(),
+	}
+}
+
+// Label identifies this node by the field it tracks distinct values for.
+func (d *Distinct) Label() string {
+	return "Distinct(" + d.Ref.Val + ")"
+}
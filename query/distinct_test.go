@@ -0,0 +1,91 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+func compileSelect(t *testing.T, fields influxql.Fields) CompiledStatement {
+	t.Helper()
+
+	stmt := &influxql.SelectStatement{
+		Fields:  fields,
+		Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+	}
+	compiled, err := Compile(stmt)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return compiled
+}
+
+func TestCompile_Distinct(t *testing.T) {
+	fields := influxql.Fields{
+		{Expr: &influxql.Call{Name: "distinct", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+	}
+	compiled := compileSelect(t, fields)
+
+	c := compiled.(*compiledStatement)
+	if c.Distinct == nil {
+		t.Fatal("expected compileDistinct to set c.Distinct")
+	}
+	if c.Distinct.Ref.Val != "value" {
+		t.Fatalf("unexpected distinct ref: %s", c.Distinct.Ref.Val)
+	}
+}
+
+func TestCompile_CountDistinct(t *testing.T) {
+	fields := influxql.Fields{
+		{Expr: &influxql.Call{
+			Name: "count",
+			Args: []influxql.Expr{
+				&influxql.Call{Name: "distinct", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}},
+			},
+		}},
+	}
+	compiled := compileSelect(t, fields)
+
+	c := compiled.(*compiledStatement)
+
+	// Unlike compileDistinct, compileCountDistinct does not set c.Distinct:
+	// the Distinct node here is just internal plumbing so count() only sees
+	// one point per unique value, not a statement-level distinct() query,
+	// so it must not trip the "distinct() cannot be combined with other
+	// fields/functions" checks in linkAuxiliaryFields/validateFields.
+	if c.Distinct != nil {
+		t.Fatal("expected compileCountDistinct to leave c.Distinct unset")
+	}
+	if len(c.FunctionCalls) != 1 {
+		t.Fatalf("expected 1 function call, got %d", len(c.FunctionCalls))
+	}
+
+	var found bool
+	for _, n := range c.Nodes {
+		if _, ok := n.(*Distinct); ok {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected a Distinct node in the compiled plan")
+	}
+}
+
+func TestCompile_DistinctRejectsMixedFields(t *testing.T) {
+	stmt := &influxql.SelectStatement{
+		Fields: influxql.Fields{
+			{Expr: &influxql.Call{Name: "distinct", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+			{Expr: &influxql.VarRef{Val: "other"}},
+		},
+		Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+	}
+
+	_, err := Compile(stmt)
+	if err == nil {
+		t.Fatal("expected an error mixing distinct() with another field")
+	}
+	if !strings.Contains(err.Error(), "distinct") {
+		t.Fatalf("expected error to mention distinct, got %q", err)
+	}
+}
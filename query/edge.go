@@ -0,0 +1,183 @@
+package query
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Point is a single value read from or written to an edge. It is the unit
+// of data that flows through the plan between nodes.
+type Point struct {
+	Name  string
+	Tags  map[string]string
+	Time  int64
+	Value interface{}
+
+	// Aux holds the values of any auxiliary fields requested alongside
+	// this point, in the order they were registered.
+	Aux []interface{}
+}
+
+// Node represents a single node in a compiled query plan. Nodes are wired
+// together with edges; a node reads points from its input edge(s) and
+// writes the points it produces to its output edge.
+type Node interface {
+	// Execute runs this node to completion, reading from its input edges
+	// and writing to its output edge, until its input is exhausted or ctx
+	// is cancelled.
+	Execute(ctx context.Context) error
+
+	// Stats returns this node's point/byte counters and timing, gathered
+	// from the edges it reads from and writes to.
+	Stats() NodeStats
+}
+
+// EdgeStats holds the point/byte counters and timing for a single edge,
+// shared by both the edge's producer (as its output) and consumer (as its
+// input). There is no WallTime here: an edge is created at compile time,
+// long before any node starts running, so the time elapsed since then isn't
+// a meaningful measure of processing time. Node.Stats implementations track
+// their own wall time instead; see execTiming in plan.go.
+type EdgeStats struct {
+	Points    int64
+	Bytes     int64
+	FirstByte time.Duration
+}
+
+// edge is the channel-based pipe shared by the two halves of a connection
+// between a producing node and a consuming node.
+type edge struct {
+	points chan Point
+
+	mu        sync.Mutex
+	start     time.Time
+	count     int64
+	bytes     int64
+	firstByte time.Duration
+	sawFirst  bool
+}
+
+func newEdge() *edge {
+	return &edge{points: make(chan Point, 64), start: time.Now()}
+}
+
+func (e *edge) recordEmit(p Point) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.sawFirst {
+		e.firstByte = time.Since(e.start)
+		e.sawFirst = true
+	}
+	e.count++
+	e.bytes += pointSize(p)
+}
+
+func (e *edge) stats() EdgeStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EdgeStats{
+		Points:    e.count,
+		Bytes:     e.bytes,
+		FirstByte: e.firstByte,
+	}
+}
+
+// pointSize estimates the number of bytes a point occupies, for the bytes
+// counter in EdgeStats.
+func pointSize(p Point) int64 {
+	size := int64(len(p.Name) + 8)
+	for k, v := range p.Tags {
+		size += int64(len(k) + len(v))
+	}
+	size += int64(8 * len(p.Aux))
+	return size
+}
+
+// InputEdge is held by the node that produces points onto an edge. Despite
+// the name, it is assigned to a producing node's Output field: it is the
+// producer's handle for writing into the edge.
+type InputEdge struct {
+	e    *edge
+	Node Node
+}
+
+// Emit writes a point to the edge, returning early if ctx is cancelled.
+func (in *InputEdge) Emit(ctx context.Context, p Point) error {
+	select {
+	case in.e.points <- p:
+		in.e.recordEmit(p)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the point/byte counters and timing observed on this edge
+// so far.
+func (in *InputEdge) Stats() EdgeStats { return in.e.stats() }
+
+// Close signals that no more points will be written to this edge.
+func (in *InputEdge) Close() {
+	close(in.e.points)
+}
+
+// OutputEdge is held by the node that reads points from an edge (or, if
+// Node is nil, by whatever is waiting to decide what consumes it, such as
+// a not-yet-wired query result). It is the consumer's handle for reading
+// from the edge.
+type OutputEdge struct {
+	e    *edge
+	Node Node
+}
+
+// Next reads the next point from the edge. The second return value is
+// false once the edge has been closed and drained.
+func (out *OutputEdge) Next(ctx context.Context) (Point, bool, error) {
+	select {
+	case p, ok := <-out.e.points:
+		return p, ok, nil
+	case <-ctx.Done():
+		return Point{}, false, ctx.Err()
+	}
+}
+
+// Stats returns the point/byte counters and timing observed on this edge
+// so far.
+func (out *OutputEdge) Stats() EdgeStats { return out.e.stats() }
+
+// Iterator returns the output edge itself; it exists so call sites that
+// only have an expression to resolve can read from an OutputEdge the same
+// way they would from any other iterator-producing node.
+func (out *OutputEdge) Iterator() *OutputEdge { return out }
+
+// Insert splices node into the middle of this edge. The node that used to
+// read from this edge (if any) is left untouched; node becomes the new
+// reader instead, and out is rewritten in place to represent node's own
+// output edge so that anyone already holding out keeps reading correct
+// data. Insert returns node's new input and output edges, in the same
+// order as the Input/Output fields found on every node.
+func (out *OutputEdge) Insert(node Node) (*OutputEdge, *InputEdge) {
+	nodeInput := &OutputEdge{e: out.e, Node: node}
+
+	newIn, newOut := NewEdge(node)
+	*out = *newOut
+
+	return nodeInput, newIn
+}
+
+// NewEdge creates a new, unconnected edge produced by output. The returned
+// InputEdge is meant to be stored on output's Output field; the returned
+// OutputEdge is handed to whatever will eventually consume it.
+func NewEdge(output Node) (*InputEdge, *OutputEdge) {
+	e := newEdge()
+	return &InputEdge{e: e}, &OutputEdge{e: e}
+}
+
+// AddEdge creates a new edge directly connecting output (the producer) to
+// input (the consumer).
+func AddEdge(output, input Node) (*InputEdge, *OutputEdge) {
+	in, out := NewEdge(output)
+	out.Node = input
+	return in, out
+}
@@ -0,0 +1,63 @@
+package query
+
+import "context"
+
+// FunctionCall evaluates an aggregate or selector function, such as
+// count() or max(), over the points read from Input.
+type FunctionCall struct {
+	Name string
+
+	// Args holds any scalar arguments the function needs beyond the field
+	// it operates on, such as the percentile for percentile() or the
+	// sample size for sample().
+	Args []interface{}
+
+	Input  *OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// Execute drains every point from Input, checking ctx.Done() between each
+// one so a long-running drain can still be cancelled. It does not yet
+// reduce those points with Name or write anything to Output: evaluating
+// the aggregate/selector functions themselves is not implemented, so every
+// query that bottoms out in a FunctionCall produces zero result rows.
+func (c *FunctionCall) Execute(ctx context.Context) error {
+	c.started()
+	defer c.stopped()
+	defer c.Output.Close()
+	defer func() { recordNodeStats(ctx, c) }()
+
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		_, ok, err := c.Input.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// Stats returns the counters for Input and Output.
+func (c *FunctionCall) Stats() NodeStats {
+	in := c.Input.Stats()
+	out := c.Output.Stats()
+	return NodeStats{
+		PointsIn:  in.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  c.wallTime(),
+	}
+}
+
+// Label identifies this node by the function it evaluates.
+func (c *FunctionCall) Label() string {
+	return "FunctionCall(" + c.Name + ")"
+}
@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// IteratorCreator is the leaf plan node that reads raw points for a single
+// measurement out of the storage engine.
+type IteratorCreator struct {
+	Expr influxql.Expr
+
+	// AuxiliaryFields points back at the compiler's shared AuxiliaryFields
+	// so that, once known, the fields this source needs to read can be
+	// determined after compilation finishes.
+	AuxiliaryFields **AuxiliaryFields
+
+	// Condition holds the statement's WHERE clause, if any, so it can be
+	// pushed down to the storage engine.
+	Condition influxql.Expr
+
+	Measurement *influxql.Measurement
+	Output      *InputEdge
+
+	execTiming
+}
+
+// Execute checks ctx and then closes Output without writing any points to
+// it; it does not read from the storage engine itself.
+func (ic *IteratorCreator) Execute(ctx context.Context) error {
+	ic.started()
+	defer ic.stopped()
+	defer ic.Output.Close()
+	defer func() { recordNodeStats(ctx, ic) }()
+
+	if err := checkContext(ctx); err != nil {
+		return err
+	}
+	// Reading from the storage engine is outside the scope of the plan
+	// itself; shards are expected to be wired in by the executor that owns
+	// this IteratorCreator.
+	return nil
+}
+
+// Stats returns the counters for the output edge this IteratorCreator
+// writes points to.
+func (ic *IteratorCreator) Stats() NodeStats {
+	out := ic.Output.Stats()
+	return NodeStats{
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  ic.wallTime(),
+	}
+}
+
+// Label identifies this node by the measurement it reads from.
+func (ic *IteratorCreator) Label() string {
+	return "IteratorCreator(" + ic.Measurement.Name + ")"
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// BinaryExprSide identifies which side of a binary expression held the
+// literal that LiteralBinaryExpr was built from.
+type BinaryExprSide int
+
+const (
+	LHS BinaryExprSide = iota
+	RHS
+)
+
+// LiteralBinaryExpr evaluates a binary expression where one side is a
+// literal, such as value * 2 or 100 - usage, applying the literal to each
+// point read from Input without spawning an IteratorCreator for it.
+type LiteralBinaryExpr struct {
+	Side    BinaryExprSide
+	Literal influxql.Literal
+	Op      influxql.Token
+
+	Input  *OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// Execute reads every point from Input, combines it with Literal according
+// to Op and Side, and writes the result to Output.
+func (n *LiteralBinaryExpr) Execute(ctx context.Context) error {
+	n.started()
+	defer n.stopped()
+	defer n.Output.Close()
+	defer func() { recordNodeStats(ctx, n) }()
+
+	lit := literalValue(n.Literal)
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		p, ok, err := n.Input.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !ok {
+			return nil
+		}
+
+		out := Point{Name: p.Name, Tags: p.Tags, Time: p.Time}
+		if n.Side == LHS {
+			out.Value = evalBinaryExpr(n.Op, lit, p.Value)
+		} else {
+			out.Value = evalBinaryExpr(n.Op, p.Value, lit)
+		}
+
+		if err := n.Output.Emit(ctx, out); err != nil {
+			return contextError(ctx)
+		}
+	}
+}
+
+// literalValue returns the Go value held by an influxql.Literal.
+func literalValue(lit influxql.Literal) interface{} {
+	switch lit := lit.(type) {
+	case *influxql.NumberLiteral:
+		return lit.Val
+	case *influxql.IntegerLiteral:
+		return lit.Val
+	case *influxql.StringLiteral:
+		return lit.Val
+	case *influxql.BooleanLiteral:
+		return lit.Val
+	default:
+		return nil
+	}
+}
+
+// Stats returns the counters for Input and Output.
+func (n *LiteralBinaryExpr) Stats() NodeStats {
+	in := n.Input.Stats()
+	out := n.Output.Stats()
+	return NodeStats{
+		PointsIn:  in.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  n.wallTime(),
+	}
+}
+
+// Label identifies this node by the operator it evaluates.
+func (n *LiteralBinaryExpr) Label() string {
+	return "LiteralBinaryExpr(" + n.Op.String() + ")"
+}
@@ -0,0 +1,115 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+func TestCompile_LiteralBinaryExpr(t *testing.T) {
+	var value influxql.Expr = &influxql.VarRef{Val: "value"}
+
+	intLit := &influxql.IntegerLiteral{Val: 2}
+	numLit := &influxql.NumberLiteral{Val: 1.5}
+	strLit := &influxql.StringLiteral{Val: "foo"}
+	boolLit := &influxql.BooleanLiteral{Val: true}
+
+	tests := []struct {
+		name string
+		lit  influxql.Literal
+		side BinaryExprSide
+		expr influxql.Expr
+	}{
+		{name: "IntegerLiteral/LHS", lit: intLit, side: LHS,
+			expr: &influxql.BinaryExpr{Op: influxql.MUL, LHS: intLit, RHS: value}},
+		{name: "IntegerLiteral/RHS", lit: intLit, side: RHS,
+			expr: &influxql.BinaryExpr{Op: influxql.MUL, LHS: value, RHS: intLit}},
+		{name: "NumberLiteral/LHS", lit: numLit, side: LHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: numLit, RHS: value}},
+		{name: "NumberLiteral/RHS", lit: numLit, side: RHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: value, RHS: numLit}},
+		{name: "StringLiteral/LHS", lit: strLit, side: LHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: strLit, RHS: value}},
+		{name: "StringLiteral/RHS", lit: strLit, side: RHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: value, RHS: strLit}},
+		{name: "BooleanLiteral/LHS", lit: boolLit, side: LHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: boolLit, RHS: value}},
+		{name: "BooleanLiteral/RHS", lit: boolLit, side: RHS,
+			expr: &influxql.BinaryExpr{Op: influxql.ADD, LHS: value, RHS: boolLit}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled := compileSelect(t, influxql.Fields{{Expr: tt.expr}})
+			c := compiled.(*compiledStatement)
+
+			node := soleLiteralBinaryExpr(t, c)
+			if node.Side != tt.side {
+				t.Errorf("unexpected side: got %v, want %v", node.Side, tt.side)
+			}
+			if node.Literal != tt.lit {
+				t.Errorf("unexpected literal: got %#v, want %#v", node.Literal, tt.lit)
+			}
+		})
+	}
+}
+
+// TestCompile_LiteralBinaryExprChain compiles (value + 1) * 2 and verifies
+// it produces two chained LiteralBinaryExpr nodes rather than falling
+// through to the non-literal, both-sides-compiled branch.
+func TestCompile_LiteralBinaryExprChain(t *testing.T) {
+	inner := &influxql.BinaryExpr{
+		Op:  influxql.ADD,
+		LHS: &influxql.VarRef{Val: "value"},
+		RHS: &influxql.IntegerLiteral{Val: 1},
+	}
+	outer := &influxql.BinaryExpr{
+		Op:  influxql.MUL,
+		LHS: inner,
+		RHS: &influxql.IntegerLiteral{Val: 2},
+	}
+
+	compiled := compileSelect(t, influxql.Fields{{Expr: outer}})
+	c := compiled.(*compiledStatement)
+
+	var chain []*LiteralBinaryExpr
+	for _, n := range c.Nodes {
+		if lbe, ok := n.(*LiteralBinaryExpr); ok {
+			chain = append(chain, lbe)
+		}
+	}
+	if len(chain) != 2 {
+		t.Fatalf("expected 2 LiteralBinaryExpr nodes, got %d", len(chain))
+	}
+
+	innerNode, outerNode := chain[0], chain[1]
+	if innerNode.Op != influxql.ADD || innerNode.Side != RHS {
+		t.Errorf("unexpected inner node: op=%v side=%v", innerNode.Op, innerNode.Side)
+	}
+	if outerNode.Op != influxql.MUL || outerNode.Side != RHS {
+		t.Errorf("unexpected outer node: op=%v side=%v", outerNode.Op, outerNode.Side)
+	}
+	if outerNode.Input.e != innerNode.Output.e {
+		t.Error("expected outer node's Input to read from inner node's Output")
+	}
+}
+
+// soleLiteralBinaryExpr returns the single *LiteralBinaryExpr node compiled
+// into c. linkAuxiliaryFields may append its own Merge/IteratorCreator nodes
+// after the field's node, so the node under test cannot be found by
+// position in c.Nodes; filter by type instead, as
+// TestCompile_LiteralBinaryExprChain already does for its two-node chain.
+func soleLiteralBinaryExpr(t *testing.T, c *compiledStatement) *LiteralBinaryExpr {
+	t.Helper()
+
+	var found []*LiteralBinaryExpr
+	for _, n := range c.Nodes {
+		if lbe, ok := n.(*LiteralBinaryExpr); ok {
+			found = append(found, lbe)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected exactly 1 LiteralBinaryExpr node, got %d", len(found))
+	}
+	return found[0]
+}
@@ -0,0 +1,25 @@
+package query
+
+import "github.com/influxdata/influxdb/influxql"
+
+// MeasurementExpander resolves regex measurement sources (FROM /^cpu.*/)
+// and multi-measurement sources into the concrete, flat set of
+// measurements they match within the target database and retention
+// policy. A real implementation is backed by a meta store and injected
+// into Compile with WithMeasurementExpander; tests can stub the interface
+// directly.
+type MeasurementExpander interface {
+	ExpandSources(sources influxql.Sources) (influxql.Sources, error)
+}
+
+// CompileOption configures the compiler used by Compile.
+type CompileOption func(*compiledStatement)
+
+// WithMeasurementExpander configures Compile to run expander over the
+// statement's sources before compiling its fields, flattening any regex or
+// multi-measurement source into one entry per concrete measurement.
+func WithMeasurementExpander(expander MeasurementExpander) CompileOption {
+	return func(c *compiledStatement) {
+		c.Expander = expander
+	}
+}
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+type stubExpander struct {
+	sources influxql.Sources
+	err     error
+}
+
+func (s *stubExpander) ExpandSources(influxql.Sources) (influxql.Sources, error) {
+	return s.sources, s.err
+}
+
+func TestCompile_ExpanderExpandsSources(t *testing.T) {
+	expander := &stubExpander{sources: influxql.Sources{
+		&influxql.Measurement{Name: "cpu_1"},
+		&influxql.Measurement{Name: "cpu_2"},
+	}}
+
+	stmt := &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile("^cpu")}}},
+	}
+
+	compiled, err := Compile(stmt, WithMeasurementExpander(expander))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c := compiled.(*compiledStatement)
+	if len(c.Sources) != 2 {
+		t.Fatalf("expected the expanded sources to replace the regex source, got %d sources", len(c.Sources))
+	}
+}
+
+func TestCompile_ExpanderErrorPropagates(t *testing.T) {
+	expander := &stubExpander{err: errors.New("meta store unavailable")}
+
+	stmt := &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile("^cpu")}}},
+	}
+
+	_, err := Compile(stmt, WithMeasurementExpander(expander))
+	if err == nil || !strings.Contains(err.Error(), "meta store unavailable") {
+		t.Fatalf("expected the expander's error to propagate, got %v", err)
+	}
+}
+
+func TestCompile_RejectsUnexpandedRegexSource(t *testing.T) {
+	stmt := &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile("^cpu")}}},
+	}
+
+	_, err := Compile(stmt)
+	if err == nil {
+		t.Fatal("expected an error compiling an unexpanded regex source without a MeasurementExpander")
+	}
+	if !strings.Contains(err.Error(), "MeasurementExpander") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCompile_RejectsUnexpandedWildcardSource(t *testing.T) {
+	stmt := &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{}},
+	}
+
+	_, err := Compile(stmt)
+	if err == nil {
+		t.Fatal("expected an error compiling a nameless source without a MeasurementExpander")
+	}
+	if !strings.Contains(err.Error(), "MeasurementExpander") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+package query
+
+import "context"
+
+// Merge reads points from any number of input edges into a single output
+// edge. Each input is typically the output of an IteratorCreator for one
+// source in the statement. Execute currently drains each input fully
+// before moving to the next, i.e. concatenation rather than a fan-in merge;
+// this is harmless while IteratorCreator is a no-op stub, but will need to
+// become concurrent once real per-shard iterators exist so a slow or
+// blocked source can't head-of-line-block the others.
+type Merge struct {
+	Inputs []*OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// AddInput wires node up as one of the inputs to this Merge and returns
+// node's output edge so the caller can assign it to node's Output field.
+func (m *Merge) AddInput(node Node) *InputEdge {
+	in, out := NewEdge(node)
+	out.Node = m
+	m.Inputs = append(m.Inputs, out)
+	return in
+}
+
+// AddInputEdge wires an already-produced output edge up as one of the
+// inputs to this Merge, such as the projected output of a subquery.
+func (m *Merge) AddInputEdge(out *OutputEdge) {
+	out.Node = m
+	m.Inputs = append(m.Inputs, out)
+}
+
+// Execute reads from every input until each is exhausted, forwarding
+// points to Output, and observes ctx.Done() between points so a timeout or
+// kill is noticed promptly rather than only once all inputs drain.
+func (m *Merge) Execute(ctx context.Context) error {
+	m.started()
+	defer m.stopped()
+	defer m.Output.Close()
+	defer func() { recordNodeStats(ctx, m) }()
+
+	for _, in := range m.Inputs {
+		for {
+			if err := checkContext(ctx); err != nil {
+				return err
+			}
+
+			p, ok, err := in.Next(ctx)
+			if err != nil {
+				return contextError(ctx)
+			}
+			if !ok {
+				break
+			}
+			if err := m.Output.Emit(ctx, p); err != nil {
+				return contextError(ctx)
+			}
+		}
+	}
+	return nil
+}
+
+// Stats returns counters summed across every input edge alongside the
+// counters for Output. WallTime reflects the time this Merge itself has
+// spent in Execute, not the edges' age.
+func (m *Merge) Stats() NodeStats {
+	var stats NodeStats
+	for _, in := range m.Inputs {
+		s := in.Stats()
+		stats.PointsIn += s.Points
+	}
+
+	out := m.Output.Stats()
+	stats.PointsOut = out.Points
+	stats.BytesOut = out.Bytes
+	stats.FirstByte = out.FirstByte
+	stats.WallTime = m.wallTime()
+	return stats
+}
@@ -0,0 +1,332 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// contextKey is a private type for the context keys defined by this package
+// so they cannot collide with keys from other packages.
+type contextKey string
+
+// QueryIDKey and QueryDurationKey are the context keys under which the
+// running query's id and configured timeout are stored. Nodes that need to
+// identify the statement they are part of (for logging, tracing, etc.) can
+// read them from the context passed to Execute.
+const (
+	QueryIDKey       contextKey = "query_id"
+	QueryDurationKey contextKey = "query_duration"
+)
+
+var (
+	// ErrQueryTimeoutLimitExceeded is returned when a query runs longer
+	// than its configured timeout: by Select if the deadline has already
+	// passed when it is called, otherwise by the plan's nodes and so by the
+	// function returned from Plan.Execute.
+	ErrQueryTimeoutLimitExceeded = errors.New("query timeout reached")
+
+	// ErrQueryAborted is returned, in the same places as
+	// ErrQueryTimeoutLimitExceeded, when a query is cancelled by a call to
+	// QueryManager.KillQuery or because the caller's context was cancelled
+	// for some other reason.
+	ErrQueryAborted = errors.New("query aborted")
+)
+
+// DefaultQueryTimeout is the timeout applied to a query when one isn't
+// specified explicitly. A zero value disables the default timeout.
+var DefaultQueryTimeout time.Duration
+
+// checkContext translates a cancelled context into the sentinel error that
+// distinguishes a timeout from an explicit kill.
+func checkContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return contextError(ctx)
+	default:
+		return nil
+	}
+}
+
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return ErrQueryTimeoutLimitExceeded
+	}
+	return ErrQueryAborted
+}
+
+// Plan holds the root edges of a compiled statement that is ready to be
+// executed.
+type Plan struct {
+	// Targets holds the output edges that will be read from to produce the
+	// statement's result.
+	Targets []*OutputEdge
+
+	// Nodes holds every node in the plan. Execute runs each of these
+	// concurrently; callers must be draining Targets before they wait on
+	// the function Execute returns, or a node can block forever writing to
+	// a full output edge.
+	Nodes []Node
+
+	stats *PlanStats
+}
+
+// AddTarget registers out as one of the result edges for this plan.
+func (p *Plan) AddTarget(out *OutputEdge) {
+	p.Targets = append(p.Targets, out)
+}
+
+// Execute starts every node in the plan running concurrently against ctx
+// and returns a function that blocks until they have all finished,
+// returning the first error any of them encountered, if any. The caller
+// must begin reading from Targets before calling the returned function,
+// since a node will block writing to a full output edge until a target (or
+// the node consuming it) reads from it.
+//
+// If Select has attached a *PlanStats to the plan, Execute carries it on
+// ctx so every node's recordNodeStats call can find it; callers don't need
+// to wire this up themselves to get working Stats() after execution.
+func (p *Plan) Execute(ctx context.Context) func() error {
+	if p.stats != nil {
+		ctx = context.WithValue(ctx, planStatsKey{}, p.stats)
+	}
+
+	errCh := make(chan error, len(p.Nodes))
+
+	var wg sync.WaitGroup
+	wg.Add(len(p.Nodes))
+	for _, n := range p.Nodes {
+		n := n
+		go func() {
+			defer wg.Done()
+			if err := n.Execute(ctx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	return func() error {
+		wg.Wait()
+		close(errCh)
+		for err := range errCh {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Stats returns a snapshot of the per-node statistics gathered while this
+// plan has been executing, keyed by node label (e.g. "FunctionCall(count)").
+// It is nil until Select has attached a *PlanStats to the plan.
+func (p *Plan) Stats() map[string]NodeStats {
+	if p.stats == nil {
+		return nil
+	}
+	return p.stats.snapshot()
+}
+
+// NodeStats holds the point/byte counters and timing for a single plan
+// node, as reported by its Stats method.
+type NodeStats struct {
+	PointsIn  int64
+	PointsOut int64
+	BytesOut  int64
+	WallTime  time.Duration
+	FirstByte time.Duration
+}
+
+// PlanStats accumulates the most recent NodeStats reported by each node in
+// a plan, keyed by node label, so EXPLAIN ANALYZE style output can be
+// rendered once execution finishes.
+type PlanStats struct {
+	mu    sync.Mutex
+	nodes map[string]NodeStats
+}
+
+func newPlanStats() *PlanStats {
+	return &PlanStats{nodes: make(map[string]NodeStats)}
+}
+
+func (s *PlanStats) record(label string, stats NodeStats) {
+	s.mu.Lock()
+	s.nodes[label] = stats
+	s.mu.Unlock()
+}
+
+func (s *PlanStats) snapshot() map[string]NodeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]NodeStats, len(s.nodes))
+	for k, v := range s.nodes {
+		out[k] = v
+	}
+	return out
+}
+
+// execTiming tracks how long a single node spends inside its own Execute
+// call, for NodeStats.WallTime. This can't be derived from an edge's
+// timestamps because an edge is created at compile time, long before any
+// node starts running, so time.Since(edge creation) measures query
+// compile-to-finish latency rather than the node's own processing time.
+type execTiming struct {
+	mu    sync.Mutex
+	start time.Time
+	end   time.Time
+}
+
+// started marks the beginning of this node's Execute call. Nodes call this
+// as the first line of Execute.
+func (t *execTiming) started() {
+	t.mu.Lock()
+	t.start = time.Now()
+	t.mu.Unlock()
+}
+
+// stopped marks the end of this node's Execute call. Nodes call this in a
+// defer alongside started.
+func (t *execTiming) stopped() {
+	t.mu.Lock()
+	t.end = time.Now()
+	t.mu.Unlock()
+}
+
+// wallTime returns the duration between started and stopped, or the
+// duration so far if the node is still running.
+func (t *execTiming) wallTime() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.start.IsZero() {
+		return 0
+	}
+	end := t.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(t.start)
+}
+
+// planStatsKey is the context key under which the running plan's
+// *PlanStats is stored so nodes can report their Stats() once they finish
+// executing without needing a direct reference to the Plan.
+type planStatsKey struct{}
+
+// recordNodeStats reports n's current stats to the *PlanStats carried on
+// ctx, if any. Nodes call this in a defer as the last step of Execute.
+func recordNodeStats(ctx context.Context, n Node) {
+	ps, ok := ctx.Value(planStatsKey{}).(*PlanStats)
+	if !ok || ps == nil {
+		return
+	}
+	ps.record(nodeLabel(n), n.Stats())
+}
+
+// nodeLabel returns a human-readable label for n, used as the key in
+// PlanStats' node map. Nodes that implement Label() string are identified
+// by that; everything else falls back to its Go type name.
+func nodeLabel(n Node) string {
+	if l, ok := n.(interface{ Label() string }); ok {
+		return l.Label()
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+// QueryInfo describes a single statement currently tracked by a
+// QueryManager.
+type QueryInfo struct {
+	ID        uint64
+	Database  string
+	Statement string
+	Duration  time.Duration
+}
+
+// QueryManager assigns ids to running statements and tracks them so they
+// can be listed or killed while in flight.
+type QueryManager struct {
+	mu      sync.Mutex
+	nextID  uint64
+	running map[uint64]*runningQuery
+}
+
+type runningQuery struct {
+	info   QueryInfo
+	cancel context.CancelFunc
+	start  time.Time
+}
+
+// NewQueryManager returns a QueryManager ready for use.
+func NewQueryManager() *QueryManager {
+	return &QueryManager{running: make(map[uint64]*runningQuery)}
+}
+
+// Attach assigns a new query id to stmt and returns a context derived from
+// ctx that will be cancelled once timeout elapses (or never, if timeout is
+// zero and DefaultQueryTimeout is also zero). The returned done function
+// must be called once the statement finishes executing, regardless of
+// outcome, to release the tracked entry.
+func (qm *QueryManager) Attach(ctx context.Context, database, stmt string, timeout time.Duration) (newCtx context.Context, id uint64, done func()) {
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	qm.mu.Lock()
+	qm.nextID++
+	id = qm.nextID
+	ctx = context.WithValue(ctx, QueryIDKey, id)
+	ctx = context.WithValue(ctx, QueryDurationKey, timeout)
+
+	rq := &runningQuery{
+		info:   QueryInfo{ID: id, Database: database, Statement: stmt},
+		cancel: cancel,
+		start:  time.Now(),
+	}
+	qm.running[id] = rq
+	qm.mu.Unlock()
+
+	return ctx, id, func() {
+		cancel()
+		qm.mu.Lock()
+		delete(qm.running, id)
+		qm.mu.Unlock()
+	}
+}
+
+// KillQuery cancels the running statement with the given id. Its plan's
+// nodes return ErrQueryAborted once they observe the cancellation.
+func (qm *QueryManager) KillQuery(id uint64) error {
+	qm.mu.Lock()
+	rq, ok := qm.running[id]
+	qm.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no such query id: %d", id)
+	}
+	rq.cancel()
+	return nil
+}
+
+// ListQueries returns information about every statement currently tracked
+// by this QueryManager.
+func (qm *QueryManager) ListQueries() []QueryInfo {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+
+	queries := make([]QueryInfo, 0, len(qm.running))
+	for _, rq := range qm.running {
+		info := rq.info
+		info.Duration = time.Since(rq.start)
+		queries = append(queries, info)
+	}
+	return queries
+}
@@ -0,0 +1,71 @@
+package query
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryManager_AttachAndListQueries(t *testing.T) {
+	qm := NewQueryManager()
+
+	ctx, id, done := qm.Attach(context.Background(), "mydb", "SELECT * FROM cpu", 0)
+	defer done()
+
+	if id == 0 {
+		t.Fatal("expected a non-zero query id")
+	}
+	if got := ctx.Value(QueryIDKey); got != id {
+		t.Errorf("expected QueryIDKey on ctx to be %d, got %v", id, got)
+	}
+
+	queries := qm.ListQueries()
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 running query, got %d", len(queries))
+	}
+	if queries[0].ID != id || queries[0].Database != "mydb" {
+		t.Errorf("unexpected QueryInfo: %+v", queries[0])
+	}
+
+	done()
+	if queries := qm.ListQueries(); len(queries) != 0 {
+		t.Errorf("expected done() to remove the query, got %d still running", len(queries))
+	}
+}
+
+func TestQueryManager_AttachTimeout(t *testing.T) {
+	qm := NewQueryManager()
+
+	ctx, _, done := qm.Attach(context.Background(), "mydb", "SELECT * FROM cpu", time.Millisecond)
+	defer done()
+
+	select {
+	case <-ctx.Done():
+		if ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("expected DeadlineExceeded, got %v", ctx.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected ctx to be cancelled by the query timeout")
+	}
+}
+
+func TestQueryManager_KillQuery(t *testing.T) {
+	qm := NewQueryManager()
+
+	ctx, id, done := qm.Attach(context.Background(), "mydb", "SELECT * FROM cpu", 0)
+	defer done()
+
+	if err := qm.KillQuery(id); err != nil {
+		t.Fatalf("unexpected error killing query: %s", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected ctx to be cancelled by KillQuery")
+	}
+
+	if err := qm.KillQuery(id + 1); err == nil {
+		t.Fatal("expected an error killing a query id that doesn't exist")
+	}
+}
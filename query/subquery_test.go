@@ -0,0 +1,115 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+func rawSelectValue() *influxql.SelectStatement {
+	return &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+	}
+}
+
+func TestCompile_AggregateOverSubquery(t *testing.T) {
+	outer := &influxql.SelectStatement{
+		Fields: influxql.Fields{
+			{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+		},
+		Sources: influxql.Sources{&influxql.SubQuery{Statement: rawSelectValue()}},
+	}
+
+	compiled, err := Compile(outer)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c := compiled.(*compiledStatement)
+	if len(c.FunctionCalls) != 1 {
+		t.Fatalf("expected 1 function call, got %d", len(c.FunctionCalls))
+	}
+}
+
+func TestCompile_SubqueryRejectsDoubleAggregation(t *testing.T) {
+	inner := &influxql.SelectStatement{
+		Fields: influxql.Fields{
+			{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+		},
+		Sources: influxql.Sources{&influxql.Measurement{Name: "cpu"}},
+	}
+	outer := &influxql.SelectStatement{
+		Fields: influxql.Fields{
+			{Expr: &influxql.Call{Name: "count", Args: []influxql.Expr{&influxql.VarRef{Val: "mean"}}}},
+		},
+		Sources: influxql.Sources{&influxql.SubQuery{Statement: inner}},
+	}
+
+	_, err := Compile(outer)
+	if err == nil {
+		t.Fatal("expected an error aggregating an already-aggregated subquery")
+	}
+	if !strings.Contains(err.Error(), "already aggregated") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCompile_SubqueryRejectsBareField(t *testing.T) {
+	outer := &influxql.SelectStatement{
+		Fields:  influxql.Fields{{Expr: &influxql.VarRef{Val: "value"}}},
+		Sources: influxql.Sources{&influxql.SubQuery{Statement: rawSelectValue()}},
+	}
+
+	_, err := Compile(outer)
+	if err == nil {
+		t.Fatal("expected an error selecting a bare field from a subquery")
+	}
+	if !strings.Contains(err.Error(), "subquery") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCompile_SubqueryUnresolvedReference(t *testing.T) {
+	outer := &influxql.SelectStatement{
+		Fields: influxql.Fields{
+			{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "nonexistent"}}}},
+		},
+		Sources: influxql.Sources{&influxql.SubQuery{Statement: rawSelectValue()}},
+	}
+
+	_, err := Compile(outer)
+	if err == nil {
+		t.Fatal("expected an error resolving a nonexistent field against a subquery")
+	}
+	if !strings.Contains(err.Error(), "unresolved reference") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestCompile_SubqueryConditionPushdown(t *testing.T) {
+	timeOnly := &influxql.BinaryExpr{Op: influxql.GT, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.IntegerLiteral{Val: 0}}
+	nonTime := &influxql.BinaryExpr{Op: influxql.EQ, LHS: &influxql.VarRef{Val: "host"}, RHS: &influxql.StringLiteral{Val: "a"}}
+
+	newOuter := func(cond influxql.Expr) *influxql.SelectStatement {
+		return &influxql.SelectStatement{
+			Fields: influxql.Fields{
+				{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}},
+			},
+			Sources:   influxql.Sources{&influxql.SubQuery{Statement: rawSelectValue()}},
+			Condition: cond,
+		}
+	}
+
+	if _, err := Compile(newOuter(timeOnly)); err != nil {
+		t.Errorf("expected a time-only condition to push down into the subquery, got error: %s", err)
+	}
+
+	_, err := Compile(newOuter(nonTime))
+	if err == nil {
+		t.Fatal("expected an error pushing a non-time condition down into a subquery")
+	}
+	if !strings.Contains(err.Error(), "non-time") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
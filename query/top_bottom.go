@@ -0,0 +1,158 @@
+package query
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// TopBottomReduce computes the top or bottom N points out of whatever
+// points it is given. Tags is carried on the node but is not used to group
+// points: the N points are chosen across every point read. It runs ahead of a
+// TopBottomFinal so that a per-shard limit can be applied before points
+// from every shard are combined.
+type TopBottomReduce struct {
+	Name string // "top" or "bottom"
+	Tags []*influxql.VarRef
+	N    int
+
+	Input  *OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// Execute reads every point from Input and writes only the N points with
+// the largest (top) or smallest (bottom) value across all of them; points
+// are not grouped by Tags.
+func (r *TopBottomReduce) Execute(ctx context.Context) error {
+	r.started()
+	defer r.stopped()
+	defer r.Output.Close()
+	defer func() { recordNodeStats(ctx, r) }()
+	return reduceTopBottom(ctx, r.Name, r.N, r.Input, r.Output)
+}
+
+// Stats returns the counters for Input and Output.
+func (r *TopBottomReduce) Stats() NodeStats {
+	in := r.Input.Stats()
+	out := r.Output.Stats()
+	return NodeStats{
+		PointsIn:  in.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  r.wallTime(),
+	}
+}
+
+// Label identifies this node by the function it reduces.
+func (r *TopBottomReduce) Label() string {
+	return "TopBottomReduce(" + r.Name + ")"
+}
+
+// TopBottomFinal merges the (already limited) output of one or more
+// TopBottomReduce nodes down to the final top or bottom N points.
+type TopBottomFinal struct {
+	Name string // "top" or "bottom"
+	Tags []*influxql.VarRef
+	N    int
+
+	Input  *OutputEdge
+	Output *InputEdge
+
+	execTiming
+}
+
+// Execute reads every point from Input and writes the final top or bottom
+// N points across all of them.
+func (f *TopBottomFinal) Execute(ctx context.Context) error {
+	f.started()
+	defer f.stopped()
+	defer f.Output.Close()
+	defer func() { recordNodeStats(ctx, f) }()
+	return reduceTopBottom(ctx, f.Name, f.N, f.Input, f.Output)
+}
+
+// Stats returns the counters for Input and Output.
+func (f *TopBottomFinal) Stats() NodeStats {
+	in := f.Input.Stats()
+	out := f.Output.Stats()
+	return NodeStats{
+		PointsIn:  in.Points,
+		PointsOut: out.Points,
+		BytesOut:  out.Bytes,
+		FirstByte: out.FirstByte,
+		WallTime:  f.wallTime(),
+	}
+}
+
+// Label identifies this node by the function it finalizes.
+func (f *TopBottomFinal) Label() string {
+	return "TopBottomFinal(" + f.Name + ")"
+}
+
+// reduceTopBottom drains in, keeping only the N points with the largest
+// (top) or smallest (bottom) value, and writes them to out best first:
+// largest to smallest for top, smallest to largest for bottom.
+func reduceTopBottom(ctx context.Context, name string, n int, in *OutputEdge, out *InputEdge) error {
+	var points []Point
+	for {
+		if err := checkContext(ctx); err != nil {
+			return err
+		}
+
+		p, ok, err := in.Next(ctx)
+		if err != nil {
+			return contextError(ctx)
+		}
+		if !ok {
+			break
+		}
+		points = append(points, p)
+	}
+
+	points = limitTopBottom(name, n, points)
+	for _, p := range points {
+		if err := out.Emit(ctx, p); err != nil {
+			return contextError(ctx)
+		}
+	}
+	return nil
+}
+
+// limitTopBottom returns at most the n points in points with the largest
+// (name == "top") or smallest (name == "bottom") value.
+func limitTopBottom(name string, n int, points []Point) []Point {
+	less := func(a, b float64) bool { return a < b }
+	if name == "bottom" {
+		less = func(a, b float64) bool { return a > b }
+	}
+
+	best := make([]Point, 0, n)
+	for _, p := range points {
+		v, ok := toFloat64(p.Value)
+		if !ok {
+			continue
+		}
+
+		inserted := false
+		for i, b := range best {
+			bv, _ := toFloat64(b.Value)
+			if less(bv, v) {
+				best = append(best, Point{})
+				copy(best[i+1:], best[i:])
+				best[i] = p
+				inserted = true
+				break
+			}
+		}
+		if !inserted && len(best) < n {
+			best = append(best, p)
+		}
+		if len(best) > n {
+			best = best[:n]
+		}
+	}
+	return best
+}